@@ -0,0 +1,31 @@
+// Package asset embeds the game's image assets into the binary so the
+// executable has no runtime dependency on files living next to it.
+package asset
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	_ "image/png"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed backgrounds/*.png sprites/*.png
+var images embed.FS
+
+// LoadImage decodes an embedded PNG at path (relative to this package's
+// embed root, e.g. "backgrounds/layer_far.png" or "sprites/idle_r.png")
+// into an ebiten.Image.
+func LoadImage(path string) (*ebiten.Image, error) {
+	data, err := images.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load image %s: %w", path, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("load image %s: %w", path, err)
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	CHUNK_SIZE = 32
+
+	// World depth, in rows, before the generator lays down the unbreakable
+	// floor. Chunks are unbounded horizontally but the dig still has a
+	// bottom.
+	WORLD_DEPTH_ROWS = 256
+	SURFACE_BASE_ROW = 16
+
+	// How many chunks around the player stay generated, and how many
+	// loaded chunks we tolerate in total before the LRU eviction kicks in.
+	LOAD_RADIUS       = 3
+	MAX_LOADED_CHUNKS = 200
+
+	CAVE_FILL_PROB  = 0.45
+	CAVE_ITERATIONS = 5
+
+	biomeNoiseSalt = 104729
+)
+
+const (
+	BiomePlains    = "plains"
+	BiomeDesert    = "desert"
+	BiomeSnow      = "snow"
+	BiomeMountains = "mountains"
+)
+
+// Chunk is a CHUNK_SIZE x CHUNK_SIZE tile of the world, generated
+// deterministically from the world seed and its own coordinates.
+type Chunk struct {
+	CX, CY     int
+	Biome      string
+	Blocks     [CHUNK_SIZE][CHUNK_SIZE]*Block
+	LastAccess int64
+}
+
+// chunkSeed derives a per-chunk RNG seed from the world seed and chunk
+// coordinates so the same chunk always regenerates identically.
+func chunkSeed(seed int64, cx, cy int) int64 {
+	h := hash32(seed + int64(cx)*374761393 + int64(cy)*668265263)
+	return int64(h)
+}
+
+// biomeForChunk picks a biome for an entire column of chunks using a
+// low-frequency value noise over chunk X, so biomes span many chunks.
+func biomeForChunk(seed int64, cx int) string {
+	v := octaveNoise1D(seed+biomeNoiseSalt, float64(cx)*0.08, 3, 0.5)
+	switch {
+	case v < 0.25:
+		return BiomeDesert
+	case v < 0.5:
+		return BiomePlains
+	case v < 0.75:
+		return BiomeSnow
+	default:
+		return BiomeMountains
+	}
+}
+
+// surfaceRow returns the world row of the surface at globalX. It depends
+// only on the seed and biome amplitude, never on chunk Y, so terrain is
+// seamless across chunk boundaries.
+func surfaceRow(seed int64, biome string, globalX int) int {
+	amplitude := 4.0
+	switch biome {
+	case BiomeDesert:
+		amplitude = 3
+	case BiomeSnow:
+		amplitude = 5
+	case BiomeMountains:
+		amplitude = 20
+	}
+	n := octaveNoise1D(seed, float64(globalX)*0.04, 4, 0.5)
+	return SURFACE_BASE_ROW + int((n-0.5)*2*amplitude)
+}
+
+func surfaceBlockFor(biome string) string {
+	switch biome {
+	case BiomeDesert:
+		return "sand"
+	case BiomeSnow:
+		return "snow"
+	default:
+		return "dirt"
+	}
+}
+
+func subsurfaceBlockFor(biome string) string {
+	if biome == BiomeDesert {
+		return "sand"
+	}
+	return "dirt"
+}
+
+// oreForDepth weights ore selection by depth: diamonds need to be deep,
+// gold mid-depth, coal and iron anywhere below the surface crust.
+func oreForDepth(rng *rand.Rand, globalY int) string {
+	depth := float64(globalY) / float64(WORLD_DEPTH_ROWS)
+	r := rng.Float64()
+	switch {
+	case depth > 0.75 && r < 0.35:
+		return "diamond"
+	case depth > 0.5 && r < 0.5:
+		return "gold"
+	case r < 0.5:
+		return "coal"
+	default:
+		return "iron"
+	}
+}
+
+// floorDiv is integer division that rounds toward negative infinity, so
+// chunk coordinates are stable for negative world coordinates.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// generateChunk deterministically builds a chunk's terrain, caves and ore
+// veins from the world seed.
+func (g *Game) generateChunk(cx, cy int) *Chunk {
+	biome := biomeForChunk(g.Seed, cx)
+	c := &Chunk{CX: cx, CY: cy, Biome: biome}
+	rng := rand.New(rand.NewSource(chunkSeed(g.Seed, cx, cy)))
+
+	for lx := 0; lx < CHUNK_SIZE; lx++ {
+		globalX := cx*CHUNK_SIZE + lx
+		surface := surfaceRow(g.Seed, biome, globalX)
+
+		for ly := 0; ly < CHUNK_SIZE; ly++ {
+			globalY := cy*CHUNK_SIZE + ly
+			if globalY < surface {
+				continue // air
+			}
+
+			var blockType string
+			switch {
+			case globalY >= WORLD_DEPTH_ROWS-1:
+				blockType = "unbreakable"
+			case globalY == surface:
+				blockType = surfaceBlockFor(biome)
+			case globalY < surface+5:
+				blockType = subsurfaceBlockFor(biome)
+			default:
+				blockType = "stone"
+			}
+
+			c.Blocks[ly][lx] = &Block{Type: blockType, Value: BLOCK_TYPES[blockType].Value}
+		}
+	}
+
+	g.carveCaves(c, rng)
+	g.placeOreVeins(c, rng)
+	g.applyModifiedOverlay(c)
+	return c
+}
+
+// carveCaves runs a cellular automaton over a random fill to hollow out
+// natural-looking caves below the surface crust.
+func (g *Game) carveCaves(c *Chunk, rng *rand.Rand) {
+	var solid [CHUNK_SIZE][CHUNK_SIZE]bool
+	for ly := range solid {
+		for lx := range solid[ly] {
+			solid[ly][lx] = rng.Float64() < CAVE_FILL_PROB
+		}
+	}
+
+	for iter := 0; iter < CAVE_ITERATIONS; iter++ {
+		var next [CHUNK_SIZE][CHUNK_SIZE]bool
+		for ly := range solid {
+			for lx := range solid[ly] {
+				count := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if dx == 0 && dy == 0 {
+							continue
+						}
+						ny, nx := ly+dy, lx+dx
+						if ny < 0 || ny >= CHUNK_SIZE || nx < 0 || nx >= CHUNK_SIZE || solid[ny][nx] {
+							count++ // chunk edges count as solid to avoid cave bleed
+						}
+					}
+				}
+				next[ly][lx] = count >= 5
+			}
+		}
+		solid = next
+	}
+
+	for lx := 0; lx < CHUNK_SIZE; lx++ {
+		globalX := c.CX*CHUNK_SIZE + lx
+		surface := surfaceRow(g.Seed, c.Biome, globalX)
+		for ly := 0; ly < CHUNK_SIZE; ly++ {
+			globalY := c.CY*CHUNK_SIZE + ly
+			if globalY <= surface+2 || globalY >= WORLD_DEPTH_ROWS-1 {
+				continue // keep a solid roof and never carve the bedrock
+			}
+			if !solid[ly][lx] {
+				c.Blocks[ly][lx] = nil
+			}
+		}
+	}
+}
+
+// placeOreVeins seeds a handful of points per chunk and flood-fills a
+// small bounded region of ore around each, weighted by depth.
+func (g *Game) placeOreVeins(c *Chunk, rng *rand.Rand) {
+	numVeins := 2 + rng.Intn(3)
+	for i := 0; i < numVeins; i++ {
+		lx := rng.Intn(CHUNK_SIZE)
+		ly := rng.Intn(CHUNK_SIZE)
+		block := c.Blocks[ly][lx]
+		if block == nil || block.Type != "stone" {
+			continue // only seed veins in solid rock
+		}
+		globalY := c.CY*CHUNK_SIZE + ly
+		ore := oreForDepth(rng, globalY)
+		g.floodFillOre(c, lx, ly, ore, rng)
+	}
+}
+
+func (g *Game) floodFillOre(c *Chunk, startX, startY int, ore string, rng *rand.Rand) {
+	veinSize := 4 + rng.Intn(5)
+	queue := [][2]int{{startX, startY}}
+	visited := map[[2]int]bool{{startX, startY}: true}
+	placed := 0
+
+	for len(queue) > 0 && placed < veinSize {
+		cur := queue[0]
+		queue = queue[1:]
+		lx, ly := cur[0], cur[1]
+		if lx < 0 || lx >= CHUNK_SIZE || ly < 0 || ly >= CHUNK_SIZE {
+			continue
+		}
+		block := c.Blocks[ly][lx]
+		if block == nil || block.Type != "stone" {
+			continue
+		}
+
+		c.Blocks[ly][lx] = &Block{Type: ore, Value: BLOCK_TYPES[ore].Value}
+		placed++
+
+		neighbors := [][2]int{{lx + 1, ly}, {lx - 1, ly}, {lx, ly + 1}, {lx, ly - 1}}
+		rng.Shuffle(len(neighbors), func(i, j int) { neighbors[i], neighbors[j] = neighbors[j], neighbors[i] })
+		for _, n := range neighbors {
+			if !visited[n] {
+				visited[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+}
+
+// getChunk returns the chunk at (cx, cy), generating and caching it on
+// first access, and bumps its LRU timestamp.
+func (g *Game) getChunk(cx, cy int) *Chunk {
+	key := [2]int{cx, cy}
+	c, ok := g.World[key]
+	if !ok {
+		c = g.generateChunk(cx, cy)
+		g.World[key] = c
+	}
+	c.LastAccess = g.Tick
+	return c
+}
+
+// blockAt resolves a world tile coordinate to its block, generating the
+// owning chunk if it isn't loaded yet. In level mode this reads through
+// to the loaded Tiled map instead, and may return a block that isn't
+// solid (a decorative tile on a non-collision layer) — use blockSolidAt
+// for collision/lighting checks, not a blockAt nil check.
+func (g *Game) blockAt(worldX, worldY int) *Block {
+	if g.Level != nil {
+		return g.levelBlockAt(worldX, worldY)
+	}
+	cx, cy := floorDiv(worldX, CHUNK_SIZE), floorDiv(worldY, CHUNK_SIZE)
+	c := g.getChunk(cx, cy)
+	lx, ly := worldX-cx*CHUNK_SIZE, worldY-cy*CHUNK_SIZE
+	return c.Blocks[ly][lx]
+}
+
+// blockSolidAt reports whether the tile at a world coordinate blocks
+// movement and light. In procedural mode this is just "is there a
+// block"; in level mode it reads the map's collision layers directly,
+// independent of what's merely drawn there (see blockAt).
+func (g *Game) blockSolidAt(worldX, worldY int) bool {
+	if g.Level != nil {
+		return g.Level.SolidAt(worldX, worldY) && !g.Level.IsExit(worldX, worldY)
+	}
+	return g.blockAt(worldX, worldY) != nil
+}
+
+func (g *Game) setBlockAt(worldX, worldY int, block *Block) {
+	if g.Level != nil {
+		g.levelSetBlockAt(worldX, worldY, block)
+		return
+	}
+	cx, cy := floorDiv(worldX, CHUNK_SIZE), floorDiv(worldY, CHUNK_SIZE)
+	c := g.getChunk(cx, cy)
+	lx, ly := worldX-cx*CHUNK_SIZE, worldY-cy*CHUNK_SIZE
+	c.Blocks[ly][lx] = block
+	g.recordModifiedBlock(worldX, worldY, block)
+}
+
+// recordModifiedBlock remembers a player edit as a sparse diff against
+// the deterministic procedural baseline, keyed by world coordinate, so
+// Save/Load only needs to persist what actually changed.
+func (g *Game) recordModifiedBlock(worldX, worldY int, block *Block) {
+	if g.ModifiedBlocks == nil {
+		g.ModifiedBlocks = make(map[[2]int]string)
+	}
+	key := [2]int{worldX, worldY}
+	if block == nil {
+		g.ModifiedBlocks[key] = "" // empty string marks the tile as mined out
+	} else {
+		g.ModifiedBlocks[key] = block.Type
+	}
+}
+
+// applyModifiedOverlay replays any recorded player edits that fall
+// within chunk c over its freshly generated terrain.
+func (g *Game) applyModifiedOverlay(c *Chunk) {
+	for key, blockType := range g.ModifiedBlocks {
+		cx, cy := floorDiv(key[0], CHUNK_SIZE), floorDiv(key[1], CHUNK_SIZE)
+		if cx != c.CX || cy != c.CY {
+			continue
+		}
+		lx, ly := key[0]-c.CX*CHUNK_SIZE, key[1]-c.CY*CHUNK_SIZE
+		if blockType == "" {
+			c.Blocks[ly][lx] = nil
+		} else {
+			c.Blocks[ly][lx] = &Block{Type: blockType, Value: BLOCK_TYPES[blockType].Value}
+		}
+	}
+}
+
+// streamChunks keeps the chunks around the player loaded and evicts the
+// least-recently-used ones once too many are resident. It's a no-op in
+// level mode, where the world comes from a fixed Tiled map instead.
+func (g *Game) streamChunks() {
+	if g.Level != nil {
+		return
+	}
+	playerCX := floorDiv(int(math.Floor(g.Player.X)), TILE_SIZE*CHUNK_SIZE)
+	playerCY := floorDiv(int(math.Floor(g.Player.Y)), TILE_SIZE*CHUNK_SIZE)
+
+	for dy := -LOAD_RADIUS; dy <= LOAD_RADIUS; dy++ {
+		for dx := -LOAD_RADIUS; dx <= LOAD_RADIUS; dx++ {
+			g.getChunk(playerCX+dx, playerCY+dy)
+		}
+	}
+	g.evictFarChunks(playerCX, playerCY)
+}
+
+func (g *Game) evictFarChunks(playerCX, playerCY int) {
+	if len(g.World) <= MAX_LOADED_CHUNKS {
+		return
+	}
+
+	type candidate struct {
+		key        [2]int
+		lastAccess int64
+	}
+	var candidates []candidate
+	for key, c := range g.World {
+		dx, dy := key[0]-playerCX, key[1]-playerCY
+		if dx < 0 {
+			dx = -dx
+		}
+		if dy < 0 {
+			dy = -dy
+		}
+		if dx > LOAD_RADIUS || dy > LOAD_RADIUS {
+			candidates = append(candidates, candidate{key, c.LastAccess})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastAccess < candidates[j].lastAccess })
+
+	overflow := len(g.World) - MAX_LOADED_CHUNKS
+	for i := 0; i < overflow && i < len(candidates); i++ {
+		delete(g.World, candidates[i].key)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "math"
+
+// checkBoxCollision reports whether an axis-aligned box at (x, y) with
+// the given width/height overlaps any solid world block. Used for both
+// the player and entities so floor/wall checks stay consistent.
+func (g *Game) checkBoxCollision(x, y, width, height float64) bool {
+	points := [][2]float64{
+		{x, y},
+		{x + width - 1, y},
+		{x, y + height - 1},
+		{x + width - 1, y + height - 1},
+	}
+
+	for _, p := range points {
+		gridX := int(math.Floor(p[0] / TILE_SIZE))
+		gridY := int(math.Floor(p[1] / TILE_SIZE))
+		if g.blockSolidAt(gridX, gridY) {
+			return true
+		}
+	}
+	return false
+}
+
+// aabbOverlap reports whether two axis-aligned boxes intersect.
+func aabbOverlap(x1, y1, w1, h1, x2, y2, w2, h2 float64) bool {
+	return x1 < x2+w2 && x1+w1 > x2 && y1 < y2+h2 && y1+h1 > y2
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// EnemyType holds the per-species stats that drive an Enemy's AI and
+// rendering, modeled on the creep definitions from the external
+// carotidartillery project.
+type EnemyType struct {
+	Color         color.RGBA
+	MoveSpeed     float64
+	MaxHealth     float64
+	Damage        float64
+	Width, Height float64
+}
+
+var ENEMY_TYPES = map[string]EnemyType{
+	"crawler": {Color: color.RGBA{150, 20, 20, 255}, MoveSpeed: 2, MaxHealth: 20, Damage: 5, Width: TILE_SIZE, Height: TILE_SIZE},
+	"stalker": {Color: color.RGBA{80, 0, 80, 255}, MoveSpeed: 3.5, MaxHealth: 15, Damage: 8, Width: TILE_SIZE, Height: TILE_SIZE * 1.5},
+}
+
+const (
+	enemyActionIntervalMinTicks = 30
+	enemyActionIntervalMaxTicks = 90
+	enemySpawnIntervalTicks     = 300
+	enemyContactCooldownTicks   = 30
+	enemyDeathAnimTicks         = 30
+	maxEnemies                  = 12
+
+	// repellentItem is this game's garlic analog: holding a crafted torch
+	// makes nearby enemies flee instead of seeking the player.
+	repellentItem = "torch"
+)
+
+// Enemy is a spawned hostile entity. Mode switches between "seek" and
+// "flee" on a queued timer, mirroring the queueNextAction pattern from
+// the external creep AI this is modeled on.
+type Enemy struct {
+	TypeName       string
+	X, Y           float64
+	VelX, VelY     float64
+	Health         float64
+	OnGround       bool
+	Mode           string
+	NextActionTick int64
+	LastHitTick    int64
+	Dead           bool
+	DeathTimer     int64
+}
+
+func newEnemy(typeName string, x, y float64) *Enemy {
+	t := ENEMY_TYPES[typeName]
+	return &Enemy{TypeName: typeName, X: x, Y: y, Health: t.MaxHealth, Mode: "seek"}
+}
+
+// angle returns the unit vector pointing from (x, y) toward (px, py).
+func angle(x, y, px, py float64) (float64, float64) {
+	dx, dy := px-x, py-y
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return 0, 0
+	}
+	return dx / dist, dy / dist
+}
+
+// surfaceRowAt finds the topmost solid row at world column col, used to
+// spawn enemies standing on the ground.
+func (g *Game) surfaceRowAt(col int) int {
+	if g.Level != nil {
+		for row := 0; row < g.Level.Height; row++ {
+			if g.blockSolidAt(col, row) {
+				return row
+			}
+		}
+		return -1
+	}
+	biome := biomeForChunk(g.Seed, floorDiv(col, CHUNK_SIZE))
+	return surfaceRow(g.Seed, biome, col)
+}
+
+func (g *Game) playerHoldsRepellent() bool {
+	return g.Player.Inventory[repellentItem] > 0
+}
+
+// deterministicRand returns an RNG seeded from the world seed, the
+// current tick and a monotonic per-call counter, so enemy spawn/AI rolls
+// stay reproducible across replay playback (unlike the auto-seeded
+// global math/rand source) without repeated rolls on the same tick
+// colliding on the same seed.
+func (g *Game) deterministicRand() *rand.Rand {
+	g.RandSeq++
+	return rand.New(rand.NewSource(int64(hash32(g.Seed + g.Tick*974711 + g.RandSeq*6151))))
+}
+
+// spawnEnemies periodically drops a new enemy onto a surface tile near
+// the player. Darkness halves the spawn interval, so more enemies show
+// up at night or deep underground.
+func (g *Game) spawnEnemies() {
+	interval := int64(enemySpawnIntervalTicks)
+	if g.playerLightLevel() < darknessThreshold {
+		interval /= 2
+	}
+	if g.Tick%interval != 0 || len(g.Entities) >= maxEnemies {
+		return
+	}
+
+	rng := g.deterministicRand()
+	spawnCol := int(g.Player.X/TILE_SIZE) + rng.Intn(21) - 10
+	row := g.surfaceRowAt(spawnCol)
+	if row < 0 {
+		return
+	}
+
+	typeName := "crawler"
+	if rng.Float64() < 0.4 {
+		typeName = "stalker"
+	}
+	g.Entities = append(g.Entities, newEnemy(typeName, float64(spawnCol*TILE_SIZE), float64((row-2)*TILE_SIZE)))
+}
+
+// queueNextAction re-rolls an enemy's behavior timer and decides whether
+// it should seek or flee the player, based on whether the player is
+// holding a repellent item.
+func (g *Game) queueNextAction(e *Enemy) {
+	rng := g.deterministicRand()
+	e.NextActionTick = g.Tick + int64(enemyActionIntervalMinTicks+rng.Intn(enemyActionIntervalMaxTicks-enemyActionIntervalMinTicks))
+	if g.playerHoldsRepellent() {
+		e.Mode = "flee"
+	} else {
+		e.Mode = "seek"
+	}
+}
+
+// applyEnemyBehavior accelerates the enemy toward (seek) or away from
+// (flee) the player, capped at its per-type move speed.
+func (g *Game) applyEnemyBehavior(e *Enemy) {
+	t := ENEMY_TYPES[e.TypeName]
+	px, py := g.Player.X+g.Player.Width/2, g.Player.Y+g.Player.Height/2
+	ex, ey := e.X+t.Width/2, e.Y+t.Height/2
+
+	dx, _ := angle(ex, ey, px, py)
+	if e.Mode == "flee" {
+		dx = -dx
+	}
+
+	e.VelX += dx * 0.4
+	e.VelX = math.Max(-t.MoveSpeed, math.Min(t.MoveSpeed, e.VelX))
+}
+
+// updateEnemyPhysics applies gravity and moves the enemy, refusing to
+// walk through solid tiles just like the player does.
+func (g *Game) updateEnemyPhysics(e *Enemy) {
+	t := ENEMY_TYPES[e.TypeName]
+	e.VelY = min(e.VelY+GRAVITY, MAX_FALL_SPEED)
+
+	newX := e.X + e.VelX
+	newY := e.Y + e.VelY
+
+	if !g.checkBoxCollision(newX, e.Y, t.Width, t.Height) {
+		e.X = newX
+	}
+
+	e.OnGround = false
+	if !g.checkBoxCollision(e.X, newY, t.Width, t.Height) {
+		e.Y = newY
+	} else {
+		if e.VelY > 0 {
+			e.OnGround = true
+		}
+		e.VelY = 0
+	}
+}
+
+// resolveEnemyPlayerContact deals contact damage to the player when a
+// non-fleeing enemy overlaps them, gated by a per-enemy cooldown.
+func (g *Game) resolveEnemyPlayerContact(e *Enemy) {
+	if e.Mode == "flee" {
+		return
+	}
+	t := ENEMY_TYPES[e.TypeName]
+	if !aabbOverlap(e.X, e.Y, t.Width, t.Height, g.Player.X, g.Player.Y, g.Player.Width, g.Player.Height) {
+		return
+	}
+	if g.Tick-e.LastHitTick < enemyContactCooldownTicks {
+		return
+	}
+
+	g.Player.Health -= t.Damage
+	e.LastHitTick = g.Tick
+}
+
+// updateEnemies advances AI, physics and death animations for every
+// entity, removing the ones whose death animation has finished.
+func (g *Game) updateEnemies() {
+	g.spawnEnemies()
+
+	alive := g.Entities[:0]
+	for _, e := range g.Entities {
+		if e.Dead {
+			e.DeathTimer--
+			if e.DeathTimer > 0 {
+				alive = append(alive, e)
+			}
+			continue
+		}
+
+		if g.Tick >= e.NextActionTick {
+			g.queueNextAction(e)
+		}
+		g.applyEnemyBehavior(e)
+		g.updateEnemyPhysics(e)
+		g.resolveEnemyPlayerContact(e)
+		alive = append(alive, e)
+	}
+	g.Entities = alive
+}
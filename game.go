@@ -1,25 +1,30 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
 	"math"
-	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"golang.org/x/image/font/basicfont"
+
+	"2dgame/level"
 )
 
 const (
 	WIDTH          = 1280
 	HEIGHT         = 720
 	TILE_SIZE      = 32
-	ROWS           = 64
-	COLS           = 128
 	GRAVITY        = 0.5
 	JUMP_POWER     = -10
 	MAX_FALL_SPEED = 12
@@ -40,11 +45,21 @@ var BLOCK_TYPES = map[string]BlockType{
 	"unbreakable": {Color: color.RGBA{0, 0, 0, 255}, Hardness: math.MaxInt32, Value: 9},
 	"wood":        {Color: color.RGBA{139, 69, 19, 255}, Hardness: 1, Value: 6},
 	"leaves":      {Color: color.RGBA{34, 139, 34, 255}, Hardness: 1, Value: 7},
+	"sand":        {Color: color.RGBA{237, 201, 175, 255}, Hardness: 1, Value: 8},
+	"snow":        {Color: color.RGBA{255, 250, 250, 255}, Hardness: 1, Value: 10},
+	"tile":        {Color: color.RGBA{128, 128, 128, 255}, Hardness: 2, Value: 0},
+	"coal":        {Color: color.RGBA{54, 54, 54, 255}, Hardness: 2, Value: 11},
+	"torch":       {Color: color.RGBA{255, 180, 60, 255}, Hardness: 1, Value: 12},
 }
 
 type Block struct {
 	Type  string
 	Value int
+
+	// TileGID is the 1-based tileset tile ID to draw for a level-authored
+	// block (0 means "use the flat BLOCK_TYPES color instead"); it's
+	// always 0 for procedurally generated blocks.
+	TileGID int
 }
 
 type Player struct {
@@ -57,179 +72,179 @@ type Player struct {
 	OnGround      bool
 	MiningProgress float64
 	MiningTarget  *[2]int
+
+	// Facing and Ducking drive sprite selection in Draw; AnimTick counts
+	// up while walking so the sheet animates at a steady cadence.
+	Facing   string
+	Ducking  bool
+	AnimTick int64
 }
 
 type Game struct {
-	World     [][]*Block
+	World     map[[2]int]*Chunk
+	Seed      int64
+	Tick      int64
 	Player    *Player
 	CameraX   float64
 	CameraY   float64
 	TimeOfDay float64
+
+	// Level holds a Tiled map loaded via loadLevel. When set, it replaces
+	// procedural chunk generation as the source of truth for the world.
+	Level      *level.Level
+	LevelPaths []string
+	LevelIndex int
+	TotalMined int
+
+	// Tileset is the tileset image for the loaded Level, used by drawBlock
+	// to render level tiles instead of flat colors. Nil in procedural mode.
+	Tileset *ebiten.Image
+
+	Entities    []*Enemy
+	Projectiles []*Projectile
+
+	LightMap map[[2]int]int
+
+	// Backgrounds are the parallax layers drawn behind the world, farthest
+	// first, loaded once at startup.
+	Backgrounds []backgroundLayer
+
+	// Sprites is the player's animation frame set, loaded once at startup.
+	Sprites *PlayerSpriteSheet
+
+	lastFrame InputFrame
+
+	// ModifiedBlocks is the sparse diff of player edits against the
+	// deterministic procedural baseline: world coordinate to block type,
+	// with "" meaning the tile was mined out.
+	ModifiedBlocks map[[2]int]string
+
+	ReplayRecording bool
+	ReplayPlayback  bool
+	ReplayFrames    []InputFrame
+	ReplayIndex     int
+
+	// RandSeq is a monotonic counter mixed into deterministicRand so that
+	// multiple rolls on the same tick don't collide on the same seed.
+	RandSeq int64
 }
 
 func NewGame() *Game {
 	g := &Game{
-		TimeOfDay: 0,
+		World:          make(map[[2]int]*Chunk),
+		Seed:           time.Now().UnixNano(),
+		TimeOfDay:      0,
+		ModifiedBlocks: make(map[[2]int]string),
+		Backgrounds:    loadBackgroundLayers(),
+		Sprites:        loadPlayerSpriteSheet(),
 	}
-	g.generateWorld()
 	g.createPlayer()
+	g.streamChunks()
 	return g
 }
 
-func (g *Game) generateWorld() {
-	g.World = make([][]*Block, ROWS)
-	for i := range g.World {
-		g.World[i] = make([]*Block, COLS)
-	}
-
-	// Generate base heights
-	baseHeights := make([]int, COLS)
-	for i := range baseHeights {
-		baseHeights[i] = ROWS / 2
-		if i > 0 {
-			diff := rand.Intn(5) - 2
-			baseHeights[i] = baseHeights[i-1] + diff
-			if baseHeights[i] < ROWS/4 {
-				baseHeights[i] = ROWS / 4
-			} else if baseHeights[i] > ROWS*3/4 {
-				baseHeights[i] = ROWS * 3 / 4
-			}
-		}
-	}
-
-	// Generate terrain
-	for x := 0; x < COLS; x++ {
-		height := baseHeights[x]
-		treeChance := rand.Float64()
-
-		for y := 0; y < ROWS; y++ {
-			if y >= height {
-				var blockType string
-				if y == height {
-					blockType = "dirt"
-				} else if y < height+5 {
-					blockType = "dirt"
-				} else {
-					r := rand.Float64()
-					if r < 0.01 && y < ROWS-10 {
-						blockType = "diamond"
-					} else if r < 0.03 && y < ROWS-5 {
-						blockType = "gold"
-					} else if r < 0.08 && y < ROWS-5 {
-						blockType = "iron"
-					} else {
-						blockType = "stone"
-					}
-				}
-
-				// Generate trees
-				if y == height && treeChance < 0.2 {
-					treeHeight := rand.Intn(4) + 3
-					for ty := 0; ty < treeHeight; ty++ {
-						if y-ty >= 0 {
-							g.World[y-ty][x] = &Block{
-								Type:  "wood",
-								Value: BLOCK_TYPES["wood"].Value,
-							}
-						}
-					}
-
-					// Add leaves
-					leafSizes := []int{1, 3, 5, 3, 1}
-					for ly, width := range leafSizes {
-						start := max(0, x-width/2)
-						end := min(COLS, x+width/2+1)
-						for lx := start; lx < end; lx++ {
-							if y-treeHeight-ly >= 0 {
-								g.World[y-treeHeight-ly][lx] = &Block{
-									Type:  "leaves",
-									Value: BLOCK_TYPES["leaves"].Value,
-								}
-							}
-						}
-					}
-				}
-
-				if g.World[y][x] == nil {
-					g.World[y][x] = &Block{
-						Type:  blockType,
-						Value: BLOCK_TYPES[blockType].Value,
-					}
-				}
-			}
-		}
-	}
-
-	// Add unbreakable bottom layer
-	for x := 0; x < COLS; x++ {
-		g.World[ROWS-1][x] = &Block{
-			Type:  "unbreakable",
-			Value: BLOCK_TYPES["unbreakable"].Value,
-		}
-	}
-}
-
+// createPlayer spawns the player on the surface of the chunk at world
+// column 0, the anchor column every new world generates around.
 func (g *Game) createPlayer() {
-	spawnX, spawnY := COLS/2, 0
-	for y := 0; y < ROWS; y++ {
-		if g.World[y][spawnX] != nil {
-			spawnY = y - 2
-			break
-		}
-	}
+	biome := biomeForChunk(g.Seed, 0)
+	spawnX := 0
+	spawnY := surfaceRow(g.Seed, biome, spawnX) - 2
 
 	g.Player = &Player{
 		X:         float64(spawnX * TILE_SIZE),
 		Y:         float64(spawnY * TILE_SIZE),
 		Width:     TILE_SIZE,
-		Height:    TILE_SIZE * 2,
+		Height:    playerStandHeight,
 		Health:    100,
 		MaxHealth: 100,
 		Inventory: make(map[string]int),
+		Facing:    "right",
 	}
 }
 
 func (g *Game) Update() error {
+	g.Tick++
+	g.TimeOfDay = float64(g.Tick%DAY_CYCLE_TICKS) / DAY_CYCLE_TICKS
+	g.updateLighting()
+	g.handleMetaKeys()
 	g.handleInput()
 	g.updatePlayer()
+	g.updateEnemies()
+	g.updateProjectiles()
 	g.updateCamera()
+	g.streamChunks()
+	g.checkLevelExit()
 	return nil
 }
 
+// handleMetaKeys handles debug/meta controls that sit outside normal
+// gameplay and so aren't captured in the replay stream: F5/F9 quicksave
+// and quickload, F6 toggles replay recording.
+func (g *Game) handleMetaKeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		if err := g.Save("savegame.json"); err != nil {
+			fmt.Printf("save failed: %v\n", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		if err := g.Load("savegame.json"); err != nil {
+			fmt.Printf("load failed: %v\n", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+		if g.ReplayRecording {
+			g.ReplayRecording = false
+			if err := g.SaveReplay("replay.bin"); err != nil {
+				fmt.Printf("save replay failed: %v\n", err)
+			}
+		} else {
+			g.ReplayRecording = true
+			g.ReplayFrames = nil
+		}
+	}
+}
+
 func (g *Game) handleInput() {
+	frame := g.currentInputFrame()
+	g.lastFrame = frame
+
 	// Movement
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+	if frame.Left {
 		g.Player.VelX = -6
-	} else if ebiten.IsKeyPressed(ebiten.KeyRight) {
+	} else if frame.Right {
 		g.Player.VelX = 6
 	} else {
 		g.Player.VelX *= 0.8 // Friction
 	}
 
 	// Jump
-	if ebiten.IsKeyPressed(ebiten.KeySpace) && g.Player.OnGround {
+	if frame.Jump && g.Player.OnGround {
 		g.Player.VelY = JUMP_POWER
 	}
 
 	// Mining
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
-		gridX := int((float64(x) + g.CameraX) / TILE_SIZE)
-		gridY := int((float64(y) + g.CameraY) / TILE_SIZE)
+	if frame.MineHeld {
+		gridX := int(math.Floor((float64(frame.CursorX) + g.CameraX) / TILE_SIZE))
+		gridY := int(math.Floor((float64(frame.CursorY) + g.CameraY) / TILE_SIZE))
+		g.handleMining(gridX, gridY)
+	}
 
-		if gridX >= 0 && gridX < COLS && gridY >= 0 && gridY < ROWS {
-			g.handleMining(gridX, gridY)
-		}
+	// Shooting
+	if frame.ShootPressed {
+		g.shoot(float64(frame.CursorX)+g.CameraX, float64(frame.CursorY)+g.CameraY)
 	}
-}
 
-func (g *Game) handleMining(gridX, gridY int) {
-	if g.World[gridY][gridX] == nil {
-		return
+	// Place a crafted torch at the cursor
+	if frame.TorchPressed {
+		gridX := int(math.Floor((float64(frame.CursorX) + g.CameraX) / TILE_SIZE))
+		gridY := int(math.Floor((float64(frame.CursorY) + g.CameraY) / TILE_SIZE))
+		g.placeTorch(gridX, gridY)
 	}
+}
 
-	block := g.World[gridY][gridX]
-	if block.Type == "unbreakable" {
+func (g *Game) handleMining(gridX, gridY int) {
+	block := g.blockAt(gridX, gridY)
+	if block == nil || block.Type == "unbreakable" || !g.blockSolidAt(gridX, gridY) {
 		return
 	}
 
@@ -240,16 +255,23 @@ func (g *Game) handleMining(gridX, gridY int) {
 
 	distance := math.Sqrt(math.Pow(playerCenterX-blockCenterX, 2) + math.Pow(playerCenterY-blockCenterY, 2))
 	if distance < TILE_SIZE*5 {
-		g.Player.MiningProgress += 1
+		miningRate := 1.0
+		if g.lightLevelAt(gridX, gridY) < darknessThreshold {
+			miningRate = 0.5 // darkness slows mining
+		}
+		g.Player.MiningProgress += miningRate
 		if g.Player.MiningProgress >= float64(BLOCK_TYPES[block.Type].Hardness*20) {
 			g.Player.Inventory[block.Type]++
-			g.World[gridY][gridX] = nil
+			g.setBlockAt(gridX, gridY, nil)
 			g.Player.MiningProgress = 0
+			g.TotalMined++
 		}
 	}
 }
 
 func (g *Game) updatePlayer() {
+	g.updatePlayerFacing(g.lastFrame.Down)
+
 	// Apply gravity
 	g.Player.VelY = min(g.Player.VelY+GRAVITY, MAX_FALL_SPEED)
 
@@ -275,23 +297,7 @@ func (g *Game) updatePlayer() {
 }
 
 func (g *Game) checkCollision(x, y float64) bool {
-	points := [][2]float64{
-		{x, y},
-		{x + g.Player.Width - 1, y},
-		{x, y + g.Player.Height - 1},
-		{x + g.Player.Width - 1, y + g.Player.Height - 1},
-	}
-
-	for _, p := range points {
-		gridX := int(p[0] / TILE_SIZE)
-		gridY := int(p[1] / TILE_SIZE)
-		if gridX >= 0 && gridX < COLS && gridY >= 0 && gridY < ROWS {
-			if g.World[gridY][gridX] != nil {
-				return true
-			}
-		}
-	}
-	return false
+	return g.checkBoxCollision(x, y, g.Player.Width, g.Player.Height)
 }
 
 func (g *Game) updateCamera() {
@@ -300,46 +306,104 @@ func (g *Game) updateCamera() {
 
 	g.CameraX += (targetX - g.CameraX) * 0.1
 	g.CameraY += (targetY - g.CameraY) * 0.1
+}
+
+// drawBlock renders one world tile: a tileset sub-image for a level-
+// authored block when one is loaded, falling back to the flat
+// BLOCK_TYPES color with its numeric value overlaid (procedural mode
+// always takes this path, since TileGID is only ever set in level mode).
+func (g *Game) drawBlock(screen *ebiten.Image, block *Block, screenX, screenY float32, scale float64) {
+	if block.TileGID != 0 && g.Tileset != nil {
+		cols := g.Level.TilesetColumns
+		tw, th := g.Level.TilesetTileWidth, g.Level.TilesetTileHeight
+		idx := block.TileGID - 1
+		sx, sy := (idx%cols)*tw, (idx/cols)*th
+		src := g.Tileset.SubImage(image.Rect(sx, sy, sx+tw, sy+th)).(*ebiten.Image)
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(TILE_SIZE/float64(tw), TILE_SIZE/float64(th))
+		op.GeoM.Translate(float64(screenX), float64(screenY))
+		op.ColorScale.Scale(float32(scale), float32(scale), float32(scale), 1)
+		screen.DrawImage(src, op)
+		return
+	}
 
-	g.CameraX = max(0, min(float64(COLS*TILE_SIZE-WIDTH), g.CameraX))
-	g.CameraY = max(0, min(float64(ROWS*TILE_SIZE-HEIGHT), g.CameraY))
+	vector.DrawFilledRect(screen, screenX, screenY, TILE_SIZE, TILE_SIZE, scaleColor(BLOCK_TYPES[block.Type].Color, scale), true)
+	text.Draw(screen, strconv.Itoa(block.Value), basicfont.Face7x13,
+		int(screenX)+TILE_SIZE/2-3, int(screenY)+TILE_SIZE/2+3,
+		color.White)
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	// Clear screen with sky color
-	screen.Fill(color.RGBA{135, 206, 235, 255})
+	// Clear screen with the time-of-day sky color, then the parallax
+	// background layers over it.
+	screen.Fill(g.skyColor())
+	g.drawBackgroundLayers(screen)
 
 	// Draw world
-	startRow := max(0, int(g.CameraY/TILE_SIZE))
-	endRow := min(ROWS, int((g.CameraY+HEIGHT)/TILE_SIZE+1))
-	startCol := max(0, int(g.CameraX/TILE_SIZE))
-	endCol := min(COLS, int((g.CameraX+WIDTH)/TILE_SIZE+1))
+	startRow := int(math.Floor(g.CameraY / TILE_SIZE))
+	endRow := int(math.Floor((g.CameraY+HEIGHT)/TILE_SIZE)) + 1
+	startCol := int(math.Floor(g.CameraX / TILE_SIZE))
+	endCol := int(math.Floor((g.CameraX+WIDTH)/TILE_SIZE)) + 1
 
 	for row := startRow; row < endRow; row++ {
 		for col := startCol; col < endCol; col++ {
-			if g.World[row][col] != nil {
-				block := g.World[row][col]
+			if block := g.blockAt(col, row); block != nil {
 				screenX := float32(col*TILE_SIZE - int(g.CameraX))
 				screenY := float32(row*TILE_SIZE - int(g.CameraY))
 
-				// Draw block
-				vector.DrawFilledRect(screen, screenX, screenY, TILE_SIZE, TILE_SIZE, BLOCK_TYPES[block.Type].Color, true)
-				
-				// Draw block value
-				text.Draw(screen, strconv.Itoa(block.Value), basicfont.Face7x13, 
-					int(screenX)+TILE_SIZE/2-3, int(screenY)+TILE_SIZE/2+3, 
-					color.White)
+				scale := math.Max(minLevelColorScale, float64(g.lightLevelAt(col, row))/MAX_LIGHT)
+				g.drawBlock(screen, block, screenX, screenY, scale)
 			}
 		}
 	}
 
+	// Draw NPCs (level mode only)
+	if g.Level != nil {
+		for _, npc := range g.Level.NPCs {
+			screenX := float32(npc.X*TILE_SIZE - int(g.CameraX))
+			screenY := float32(npc.Y*TILE_SIZE - int(g.CameraY))
+			vector.DrawFilledRect(screen, screenX, screenY, TILE_SIZE, TILE_SIZE, color.RGBA{230, 200, 60, 255}, true)
+			text.Draw(screen, npc.Name, basicfont.Face7x13, int(screenX), int(screenY)-4, color.White)
+		}
+	}
+
+	// Draw enemies
+	for _, e := range g.Entities {
+		t := ENEMY_TYPES[e.TypeName]
+		col := t.Color
+		if e.Dead {
+			col.A = uint8(255 * e.DeathTimer / enemyDeathAnimTicks)
+		}
+		vector.DrawFilledRect(screen,
+			float32(e.X-g.CameraX), float32(e.Y-g.CameraY),
+			float32(t.Width), float32(t.Height), col, true)
+	}
+
+	// Draw projectiles
+	for _, p := range g.Projectiles {
+		vector.DrawFilledRect(screen,
+			float32(p.X-g.CameraX), float32(p.Y-g.CameraY),
+			float32(p.Width), float32(p.Height), color.RGBA{255, 255, 0, 255}, true)
+	}
+
 	// Draw player
-	vector.DrawFilledRect(screen, 
-		float32(g.Player.X-g.CameraX), 
-		float32(g.Player.Y-g.CameraY), 
-		float32(g.Player.Width), 
-		float32(g.Player.Height), 
-		color.RGBA{0, 255, 0, 255}, true)
+	playerScale := math.Max(minPlayerColorScale, float64(g.playerLightLevel())/MAX_LIGHT)
+	if frame := g.currentPlayerFrame(); frame != nil {
+		fw, fh := frame.Bounds().Dx(), frame.Bounds().Dy()
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(g.Player.Width/float64(fw), g.Player.Height/float64(fh))
+		op.GeoM.Translate(g.Player.X-g.CameraX, g.Player.Y-g.CameraY)
+		op.ColorScale.Scale(float32(playerScale), float32(playerScale), float32(playerScale), 1)
+		screen.DrawImage(frame, op)
+	} else {
+		vector.DrawFilledRect(screen,
+			float32(g.Player.X-g.CameraX),
+			float32(g.Player.Y-g.CameraY),
+			float32(g.Player.Width),
+			float32(g.Player.Height),
+			scaleColor(color.RGBA{0, 255, 0, 255}, playerScale), true)
+	}
 
 	// Draw UI
 	g.drawUI(screen)
@@ -365,23 +429,61 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	levelFlag := flag.String("level", "", "path to a Tiled .tmx file, or a directory of them for a level progression")
+	proceduralFlag := flag.Bool("procedural", false, "force procedural world generation even if -level is set")
+	replayFlag := flag.String("replay", "", "path to a recorded replay (.bin) to play back deterministically")
+	flag.Parse()
+
 	ebiten.SetWindowSize(WIDTH, HEIGHT)
 	ebiten.SetWindowTitle("Mining Adventure")
 
-	if err := ebiten.RunGame(NewGame()); err != nil {
+	g := NewGame()
+	if *replayFlag != "" {
+		if err := g.LoadReplay(*replayFlag); err != nil {
+			fmt.Printf("could not load replay %q: %v\n", *replayFlag, err)
+		}
+	}
+	if *levelFlag != "" && !*proceduralFlag {
+		paths, err := discoverLevelPaths(*levelFlag)
+		if err != nil {
+			fmt.Printf("could not load level %q, falling back to procedural generation: %v\n", *levelFlag, err)
+		} else {
+			g.LevelPaths = paths
+			g.LevelIndex = 0
+			if err := g.loadLevel(paths[0]); err != nil {
+				fmt.Printf("could not load level %q, falling back to procedural generation: %v\n", paths[0], err)
+			}
+		}
+	}
+
+	if err := ebiten.RunGame(g); err != nil {
 		fmt.Printf("Game crashed: %v\n", err)
 	}
 }
 
-// Helper functions
-func max(a, b int) int {
-	if a > b {
-		return a
+// discoverLevelPaths resolves the -level flag to an ordered list of .tmx
+// files: the file itself, or every .tmx in a directory, sorted by name.
+func discoverLevelPaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
 	}
-	return b
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(path, "*.tmx"))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no .tmx files found in %s", path)
+	}
+	sort.Strings(matches)
+	return matches, nil
 }
 
+// Helper functions
 func min[T float64 | int](a, b T) T {
 	if a < b {
 		return a
@@ -0,0 +1,193 @@
+// Package level loads hand-authored Tiled maps (.tmx/.tsx) as an
+// alternative to the game's procedural chunk generator.
+package level
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tiled "github.com/lafriks/go-tiled"
+)
+
+// defaultRequiredQuota is how many blocks the player must mine before an
+// exit tile opens, used when the map doesn't set its own "quota" property.
+const defaultRequiredQuota = 20
+
+// TileLayer is one layer of a Level, flattened to a [Height][Width] grid
+// of tile IDs. A value of 0 means the cell is empty.
+type TileLayer struct {
+	Name  string
+	Tiles [][]int
+
+	// Collision marks this layer as feeding SolidAt. A map with a layer
+	// literally named "collision" treats only that layer as solid, so
+	// full-coverage background art layers can be decorative; a map with
+	// no such layer falls back to treating every layer as solid, for
+	// simple single-layer maps that never authored the distinction.
+	Collision bool
+}
+
+type SpawnPoint struct {
+	X, Y int
+}
+
+type ExitTile struct {
+	X, Y int
+}
+
+type NPC struct {
+	Name string
+	X, Y int
+}
+
+// Level is a loaded Tiled map along with the spawn, exit and NPC object
+// points the game cares about.
+type Level struct {
+	Name          string
+	TileWidth     int
+	TileHeight    int
+	Width         int
+	Height        int
+	Layers        []TileLayer
+	Spawn         SpawnPoint
+	Exit          ExitTile
+	NPCs          []NPC
+	RequiredQuota int
+
+	// Tileset image details for the map's first tileset, used by the
+	// game package to draw real tiles instead of flat-colored blocks.
+	// TilesetPath is empty if the map has no tileset.
+	TilesetPath       string
+	TilesetColumns    int
+	TilesetTileWidth  int
+	TilesetTileHeight int
+}
+
+// Load reads a .tmx map from disk and converts it into a Level.
+func Load(path string) (*Level, error) {
+	m, err := tiled.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("level: load %s: %w", path, err)
+	}
+
+	lvl := &Level{
+		Name:          filepath.Base(path),
+		TileWidth:     m.TileWidth,
+		TileHeight:    m.TileHeight,
+		Width:         m.Width,
+		Height:        m.Height,
+		RequiredQuota: defaultRequiredQuota,
+	}
+	if q := m.Properties.GetString("quota"); q != "" {
+		fmt.Sscanf(q, "%d", &lvl.RequiredQuota)
+	}
+
+	if len(m.Tilesets) > 0 {
+		ts := m.Tilesets[0]
+		if ts.Image != nil {
+			lvl.TilesetPath = filepath.Join(filepath.Dir(path), ts.Image.Source)
+		}
+		lvl.TilesetColumns = ts.Columns
+		lvl.TilesetTileWidth = ts.TileWidth
+		lvl.TilesetTileHeight = ts.TileHeight
+	}
+
+	// A map that authors a layer literally named "collision" uses it as
+	// the sole source of solid tiles, so other layers can be purely
+	// decorative; a map without one falls back to treating every layer
+	// as solid, matching the original single-layer behavior.
+	hasCollisionLayer := false
+	for _, l := range m.Layers {
+		if strings.EqualFold(l.Name, "collision") {
+			hasCollisionLayer = true
+			break
+		}
+	}
+
+	for _, l := range m.Layers {
+		tl := flattenLayer(l, m.Width, m.Height)
+		tl.Collision = !hasCollisionLayer || strings.EqualFold(l.Name, "collision")
+		lvl.Layers = append(lvl.Layers, tl)
+	}
+
+	for _, og := range m.ObjectGroups {
+		for _, obj := range og.Objects {
+			gx, gy := int(obj.X)/m.TileWidth, int(obj.Y)/m.TileHeight
+			switch strings.ToLower(obj.Type) {
+			case "spawn":
+				lvl.Spawn = SpawnPoint{X: gx, Y: gy}
+			case "exit":
+				lvl.Exit = ExitTile{X: gx, Y: gy}
+			case "npc":
+				lvl.NPCs = append(lvl.NPCs, NPC{Name: obj.Name, X: gx, Y: gy})
+			}
+		}
+	}
+
+	return lvl, nil
+}
+
+func flattenLayer(l *tiled.Layer, width, height int) TileLayer {
+	tl := TileLayer{Name: l.Name, Tiles: make([][]int, height)}
+	for y := 0; y < height; y++ {
+		tl.Tiles[y] = make([]int, width)
+		for x := 0; x < width; x++ {
+			tile := l.Tiles[y*width+x]
+			if tile != nil && !tile.IsNil() {
+				tl.Tiles[y][x] = int(tile.ID) + 1 // +1 so 0 still means "empty"
+			}
+		}
+	}
+	return tl
+}
+
+// SolidAt reports whether a collision layer has a tile at (x, y).
+// Out-of-bounds coordinates are treated as solid so the player can't walk
+// off the map.
+func (l *Level) SolidAt(x, y int) bool {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return true
+	}
+	for _, layer := range l.Layers {
+		if layer.Collision && layer.Tiles[y][x] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TileIDAt returns the tile ID drawn at (x, y) for tileset rendering: the
+// topmost non-empty layer's tile, so decorative layers still show through
+// once the collision tile above them is mined out. 0 means no tile.
+func (l *Level) TileIDAt(x, y int) int {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return 0
+	}
+	id := 0
+	for _, layer := range l.Layers {
+		if t := layer.Tiles[y][x]; t != 0 {
+			id = t
+		}
+	}
+	return id
+}
+
+// ClearTile removes the tile at (x, y) from collision layers only, used
+// when the player mines a level-authored block; any decorative layer
+// underneath is left in place and keeps showing through.
+func (l *Level) ClearTile(x, y int) {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return
+	}
+	for i := range l.Layers {
+		if l.Layers[i].Collision {
+			l.Layers[i].Tiles[y][x] = 0
+		}
+	}
+}
+
+// IsExit reports whether (x, y) is the level's exit tile.
+func (l *Level) IsExit(x, y int) bool {
+	return x == l.Exit.X && y == l.Exit.Y
+}
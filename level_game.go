@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"2dgame/level"
+)
+
+// loadTilesetImage decodes the level's tileset PNG from disk (it lives
+// beside the .tmx, not embedded in the binary, since levels are
+// hand-authored map files rather than shipped game assets). An empty
+// path (no tileset on the map) returns a nil image, not an error.
+func loadTilesetImage(path string) (*ebiten.Image, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("load tileset: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("load tileset: %w", err)
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
+
+// loadLevel loads a Tiled map, replacing procedural generation: the
+// player spawns at the map's spawn object and the world is read from the
+// map's layers instead of generated chunks.
+func (g *Game) loadLevel(path string) error {
+	lvl, err := level.Load(path)
+	if err != nil {
+		return err
+	}
+
+	g.Level = lvl
+	g.TotalMined = 0
+	tileset, err := loadTilesetImage(lvl.TilesetPath)
+	if err != nil {
+		fmt.Printf("failed to load tileset image: %v\n", err)
+	}
+	g.Tileset = tileset
+	g.Player = &Player{
+		X:         float64(lvl.Spawn.X * TILE_SIZE),
+		Y:         float64(lvl.Spawn.Y * TILE_SIZE),
+		Width:     TILE_SIZE,
+		Height:    playerStandHeight,
+		Health:    100,
+		MaxHealth: 100,
+		Inventory: make(map[string]int),
+		Facing:    "right",
+	}
+	return nil
+}
+
+// levelBlockAt returns a drawable block for any non-empty tile, on any
+// layer, so decorative background art shows up even where it isn't
+// solid; callers that care about collision use blockSolidAt instead.
+func (g *Game) levelBlockAt(worldX, worldY int) *Block {
+	if g.Level.IsExit(worldX, worldY) {
+		return nil
+	}
+	id := g.Level.TileIDAt(worldX, worldY)
+	if id == 0 {
+		return nil
+	}
+	return &Block{Type: "tile", Value: BLOCK_TYPES["tile"].Value, TileGID: id}
+}
+
+func (g *Game) levelSetBlockAt(worldX, worldY int, block *Block) {
+	if block == nil {
+		g.Level.ClearTile(worldX, worldY)
+	}
+}
+
+// checkLevelExit advances to the next level once the player is standing
+// on the exit tile and has mined the level's required quota.
+func (g *Game) checkLevelExit() {
+	if g.Level == nil {
+		return
+	}
+	px := int(g.Player.X) / TILE_SIZE
+	py := int(g.Player.Y) / TILE_SIZE
+	if g.Level.IsExit(px, py) && g.TotalMined >= g.Level.RequiredQuota {
+		g.advanceLevel()
+	}
+}
+
+func (g *Game) advanceLevel() {
+	g.LevelIndex++
+	if g.LevelIndex >= len(g.LevelPaths) {
+		fmt.Println("No more levels - well played!")
+		g.Level = nil
+		return
+	}
+	if err := g.loadLevel(g.LevelPaths[g.LevelIndex]); err != nil {
+		fmt.Printf("failed to load level %s: %v\n", g.LevelPaths[g.LevelIndex], err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	// One full day/night cycle takes about 10 minutes at 60 ticks/second.
+	DAY_CYCLE_TICKS = 10 * 60 * 60
+
+	MAX_LIGHT = 8
+
+	// Floors on how dark the world and the player sprite are ever
+	// allowed to render, analogous to the carotidartillery game struct's
+	// minLevelColorScale/minPlayerColorScale.
+	minLevelColorScale  = 0.12
+	minPlayerColorScale = 0.35
+
+	darknessThreshold = 3
+
+	torchWoodCost = 1
+	torchCoalCost = 1
+)
+
+var (
+	skyDayColor   = color.RGBA{135, 206, 235, 255}
+	skyNightColor = color.RGBA{10, 10, 40, 255}
+)
+
+// daylightFactor returns how "daytime" it is, 1 at noon fading to 0 at
+// midnight, derived from TimeOfDay in [0, 1).
+func (g *Game) daylightFactor() float64 {
+	return math.Cos((g.TimeOfDay-0.25)*2*math.Pi)*0.5 + 0.5
+}
+
+func (g *Game) isDaytime() bool {
+	return g.daylightFactor() > 0.5
+}
+
+// skyColor blends the day and night sky colors by the current daylight
+// factor.
+func (g *Game) skyColor() color.RGBA {
+	return lerpColor(skyNightColor, skyDayColor, g.daylightFactor())
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
+
+func scaleColor(c color.RGBA, scale float64) color.RGBA {
+	if scale > 1 {
+		scale = 1
+	}
+	return color.RGBA{
+		R: uint8(float64(c.R) * scale),
+		G: uint8(float64(c.G) * scale),
+		B: uint8(float64(c.B) * scale),
+		A: c.A,
+	}
+}
+
+type lightNode struct {
+	x, y, level int
+	seed        bool
+}
+
+// computeLightLevels runs a BFS flood fill of light from the sun (on
+// exposed surface tiles, during the day) and from placed torches, over
+// the [minCol,maxCol) x [minRow,maxRow) window. Light decreases by one
+// per step and never crosses more than one solid-to-solid boundary, so
+// it lights the near face of a wall without tunnelling through it.
+func (g *Game) computeLightLevels(minCol, minRow, maxCol, maxRow int) map[[2]int]int {
+	light := make(map[[2]int]int)
+	var queue []lightNode
+
+	if g.isDaytime() {
+		for col := minCol; col < maxCol; col++ {
+			row := g.surfaceRowAt(col)
+			if row >= minRow && row < maxRow {
+				queue = append(queue, lightNode{col, row, MAX_LIGHT, true})
+			}
+		}
+	}
+
+	for row := minRow; row < maxRow; row++ {
+		for col := minCol; col < maxCol; col++ {
+			if block := g.blockAt(col, row); block != nil && block.Type == "torch" {
+				queue = append(queue, lightNode{col, row, MAX_LIGHT, true})
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		key := [2]int{n.x, n.y}
+
+		if existing, ok := light[key]; ok && existing >= n.level {
+			continue
+		}
+		light[key] = n.level
+		if n.level <= 0 {
+			continue
+		}
+
+		// Light passes freely through air, and can spill one step out of
+		// the solid tile it originates from (sunlit ground, a torch),
+		// but otherwise stops dead at a wall.
+		if g.blockSolidAt(n.x, n.y) && !n.seed {
+			continue
+		}
+
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := n.x+d[0], n.y+d[1]
+			if nx < minCol || nx >= maxCol || ny < minRow || ny >= maxRow {
+				continue
+			}
+			queue = append(queue, lightNode{nx, ny, n.level - 1, false})
+		}
+	}
+
+	return light
+}
+
+// updateLighting refreshes the light map around the camera once per
+// tick, so both rendering and gameplay (darkness slows mining and spawns
+// more enemies) read a consistent snapshot.
+func (g *Game) updateLighting() {
+	startCol := int(math.Floor(g.CameraX/TILE_SIZE)) - MAX_LIGHT
+	endCol := int(math.Floor((g.CameraX+WIDTH)/TILE_SIZE)) + 1 + MAX_LIGHT
+	startRow := int(math.Floor(g.CameraY/TILE_SIZE)) - MAX_LIGHT
+	endRow := int(math.Floor((g.CameraY+HEIGHT)/TILE_SIZE)) + 1 + MAX_LIGHT
+	g.LightMap = g.computeLightLevels(startCol, startRow, endCol, endRow)
+}
+
+func (g *Game) lightLevelAt(x, y int) int {
+	return g.LightMap[[2]int{x, y}]
+}
+
+// playerLightLevel is the light level at the player's feet, used to
+// gate darkness-driven gameplay effects.
+func (g *Game) playerLightLevel() int {
+	return g.lightLevelAt(int(g.Player.X)/TILE_SIZE, int(g.Player.Y)/TILE_SIZE)
+}
+
+// placeTorch crafts a torch from wood and coal and places it at the
+// cursor, within mining reach, on an empty tile.
+func (g *Game) placeTorch(gridX, gridY int) bool {
+	if g.Player.Inventory["wood"] < torchWoodCost || g.Player.Inventory["coal"] < torchCoalCost {
+		return false
+	}
+	if g.blockSolidAt(gridX, gridY) {
+		return false
+	}
+
+	playerCenterX := g.Player.X + g.Player.Width/2
+	playerCenterY := g.Player.Y + g.Player.Height/2
+	blockCenterX := float64(gridX*TILE_SIZE) + TILE_SIZE/2
+	blockCenterY := float64(gridY*TILE_SIZE) + TILE_SIZE/2
+	if math.Hypot(playerCenterX-blockCenterX, playerCenterY-blockCenterY) >= TILE_SIZE*5 {
+		return false
+	}
+
+	g.Player.Inventory["wood"] -= torchWoodCost
+	g.Player.Inventory["coal"] -= torchCoalCost
+	g.setBlockAt(gridX, gridY, &Block{Type: "torch", Value: BLOCK_TYPES["torch"].Value})
+	return true
+}
@@ -0,0 +1,53 @@
+package main
+
+import "math"
+
+// hash32 turns an arbitrary 64-bit key into a well-mixed 32-bit value.
+// Used as the basis for deterministic value noise and chunk seeding.
+func hash32(x int64) uint32 {
+	h := uint64(x)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return uint32(h)
+}
+
+// latticeValue returns a deterministic pseudo-random value in [0,1) for an
+// integer lattice point, seeded by seed.
+func latticeValue(seed int64, i int) float64 {
+	h := hash32(seed*1000003 + int64(i))
+	return float64(h) / float64(math.MaxUint32)
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// valueNoise1D samples 1D value noise at x, interpolating between the
+// surrounding integer lattice points.
+func valueNoise1D(seed int64, x float64) float64 {
+	i0 := int(math.Floor(x))
+	i1 := i0 + 1
+	t := smoothstep(x - float64(i0))
+	v0 := latticeValue(seed, i0)
+	v1 := latticeValue(seed, i1)
+	return v0 + (v1-v0)*t
+}
+
+// octaveNoise1D sums several octaves of valueNoise1D for a more natural
+// looking height field.
+func octaveNoise1D(seed int64, x float64, octaves int, persistence float64) float64 {
+	total := 0.0
+	amplitude := 1.0
+	maxAmplitude := 0.0
+	frequency := 1.0
+	for o := 0; o < octaves; o++ {
+		total += valueNoise1D(seed+int64(o)*7919, x*frequency) * amplitude
+		maxAmplitude += amplitude
+		amplitude *= persistence
+		frequency *= 2
+	}
+	return total / maxAmplitude
+}
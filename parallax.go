@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"2dgame/asset"
+)
+
+// backgroundLayer is one tileable image drawn behind the world, scrolling
+// slower than the camera the further away it is (the classic parallax
+// illusion of depth).
+type backgroundLayer struct {
+	Image        *ebiten.Image
+	ScrollFactor float64 // 0 = fixed to the screen, 1 = scrolls with the world
+	VertFactor   float64
+}
+
+// loadBackgroundLayers loads the parallax background images, ordered
+// farthest to nearest. A load failure drops that layer rather than
+// aborting the game; the solid sky fill still shows through.
+func loadBackgroundLayers() []backgroundLayer {
+	specs := []struct {
+		path         string
+		scrollFactor float64
+		vertFactor   float64
+	}{
+		{"backgrounds/layer_far.png", 0.1, 0.02},
+		{"backgrounds/layer_mountains.png", 0.3, 0.05},
+		{"backgrounds/layer_hills.png", 0.6, 0.1},
+		{"backgrounds/layer_trees.png", 0.9, 0.2},
+	}
+
+	layers := make([]backgroundLayer, 0, len(specs))
+	for _, s := range specs {
+		img, err := asset.LoadImage(s.path)
+		if err != nil {
+			continue
+		}
+		layers = append(layers, backgroundLayer{Image: img, ScrollFactor: s.scrollFactor, VertFactor: s.vertFactor})
+	}
+	return layers
+}
+
+// drawBackgroundLayers tiles each parallax layer across the screen,
+// offset by the camera scaled by that layer's scroll factor, and tinted
+// by the current daylight factor so the background dims at night along
+// with the sky.
+func (g *Game) drawBackgroundLayers(screen *ebiten.Image) {
+	scale := math.Max(minLevelColorScale, g.daylightFactor())
+
+	for _, layer := range g.Backgrounds {
+		w := layer.Image.Bounds().Dx()
+		h := layer.Image.Bounds().Dy()
+		if w == 0 || h == 0 {
+			continue
+		}
+
+		offsetX := math.Mod(g.CameraX*layer.ScrollFactor, float64(w))
+		if offsetX < 0 {
+			offsetX += float64(w)
+		}
+		offsetY := math.Mod(g.CameraY*layer.VertFactor, float64(h))
+		if offsetY < 0 {
+			offsetY += float64(h)
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.ColorScale.Scale(float32(scale), float32(scale), float32(scale), 1)
+
+		for y := -offsetY; y < HEIGHT; y += float64(h) {
+			for x := -offsetX; x < WIDTH; x += float64(w) {
+				op.GeoM.Reset()
+				op.GeoM.Translate(x, y)
+				screen.DrawImage(layer.Image, op)
+			}
+		}
+	}
+}
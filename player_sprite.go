@@ -0,0 +1,134 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"2dgame/asset"
+)
+
+const (
+	playerStandHeight = TILE_SIZE * 2
+	playerDuckHeight  = TILE_SIZE
+
+	// walkAnimTicks is how long each walk frame holds before swapping,
+	// roughly a quarter second at 60 ticks/second.
+	walkAnimTicks = 15
+)
+
+// PlayerSpriteSheet holds every frame the player can be drawn in, facing
+// right, plus the left-facing mirror of each generated once at load time
+// rather than shipping duplicate art.
+type PlayerSpriteSheet struct {
+	IdleR, WalkR1, WalkR2, DuckR *ebiten.Image
+	IdleL, WalkL1, WalkL2, DuckL *ebiten.Image
+}
+
+// mirrorImage flips img horizontally into a new image of the same size.
+func mirrorImage(img *ebiten.Image) *ebiten.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	mirrored := ebiten.NewImage(w, h)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(-1, 1)
+	op.GeoM.Translate(float64(w), 0)
+	mirrored.DrawImage(img, op)
+	return mirrored
+}
+
+// loadPlayerSpriteSheet loads the embedded right-facing frames and
+// mirrors each to build the left-facing set. A load failure leaves the
+// returned sheet nil; Draw falls back to the plain player rectangle.
+func loadPlayerSpriteSheet() *PlayerSpriteSheet {
+	idleR, err := asset.LoadImage("sprites/idle_r.png")
+	if err != nil {
+		return nil
+	}
+	walkR1, err := asset.LoadImage("sprites/walk_r1.png")
+	if err != nil {
+		return nil
+	}
+	walkR2, err := asset.LoadImage("sprites/walk_r2.png")
+	if err != nil {
+		return nil
+	}
+	duckR, err := asset.LoadImage("sprites/duck_r.png")
+	if err != nil {
+		return nil
+	}
+
+	return &PlayerSpriteSheet{
+		IdleR: idleR, WalkR1: walkR1, WalkR2: walkR2, DuckR: duckR,
+		IdleL: mirrorImage(idleR), WalkL1: mirrorImage(walkR1), WalkL2: mirrorImage(walkR2), DuckL: mirrorImage(duckR),
+	}
+}
+
+// updatePlayerFacing tracks facing direction from horizontal velocity,
+// advances the walk animation ticker, and applies the duck mechanic:
+// holding down on the ground shrinks the player's collision height so
+// they can slip through a one-tile gap.
+func (g *Game) updatePlayerFacing(down bool) {
+	p := g.Player
+	if p.VelX > 0.5 {
+		p.Facing = "right"
+	} else if p.VelX < -0.5 {
+		p.Facing = "left"
+	} else if p.Facing == "" {
+		p.Facing = "right"
+	}
+
+	if p.VelX > 0.5 || p.VelX < -0.5 {
+		p.AnimTick++
+	} else {
+		p.AnimTick = 0
+	}
+
+	wantDuck := down && p.OnGround
+	if wantDuck != p.Ducking {
+		heightDelta := float64(playerStandHeight - playerDuckHeight)
+		if wantDuck {
+			p.Y += heightDelta
+			p.Height = playerDuckHeight
+		} else if !g.checkBoxCollision(p.X, p.Y-heightDelta, p.Width, playerStandHeight) {
+			p.Y -= heightDelta
+			p.Height = playerStandHeight
+		} else {
+			wantDuck = true // low ceiling: stay ducked
+		}
+		p.Ducking = wantDuck
+	}
+}
+
+// currentFrame picks the sprite for the player's current facing, ground
+// and motion state.
+func (g *Game) currentPlayerFrame() *ebiten.Image {
+	sheet := g.Sprites
+	if sheet == nil {
+		return nil
+	}
+	p := g.Player
+
+	if p.Ducking {
+		if p.Facing == "left" {
+			return sheet.DuckL
+		}
+		return sheet.DuckR
+	}
+
+	walking := p.VelX > 0.5 || p.VelX < -0.5
+	if walking && p.OnGround {
+		if (p.AnimTick/walkAnimTicks)%2 == 0 {
+			if p.Facing == "left" {
+				return sheet.WalkL1
+			}
+			return sheet.WalkR1
+		}
+		if p.Facing == "left" {
+			return sheet.WalkL2
+		}
+		return sheet.WalkR2
+	}
+
+	if p.Facing == "left" {
+		return sheet.IdleL
+	}
+	return sheet.IdleR
+}
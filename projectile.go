@@ -0,0 +1,72 @@
+package main
+
+import "math"
+
+const (
+	projectileSpeed  = 10.0
+	projectileSize   = 6.0
+	projectileDamage = 10.0
+)
+
+// Projectile is a player-fired shot that travels in a straight line until
+// it hits a world block or an enemy.
+type Projectile struct {
+	X, Y          float64
+	VelX, VelY    float64
+	Width, Height float64
+	Damage        float64
+	Dead          bool
+}
+
+// shoot spawns a projectile from the player toward (targetX, targetY) in
+// world space, used by the right-click shoot action.
+func (g *Game) shoot(targetX, targetY float64) {
+	px := g.Player.X + g.Player.Width/2
+	py := g.Player.Y + g.Player.Height/2
+	dx, dy := angle(px, py, targetX, targetY)
+
+	g.Projectiles = append(g.Projectiles, &Projectile{
+		X: px, Y: py,
+		VelX: dx * projectileSpeed, VelY: dy * projectileSpeed,
+		Width: projectileSize, Height: projectileSize,
+		Damage: projectileDamage,
+	})
+}
+
+// updateProjectiles moves projectiles, resolves collisions against the
+// world and entities, and drops the ones that hit something.
+func (g *Game) updateProjectiles() {
+	alive := g.Projectiles[:0]
+	for _, p := range g.Projectiles {
+		p.X += p.VelX
+		p.Y += p.VelY
+
+		gridX := int(math.Floor(p.X / TILE_SIZE))
+		gridY := int(math.Floor(p.Y / TILE_SIZE))
+		if g.blockSolidAt(gridX, gridY) {
+			continue // absorbed by the world
+		}
+
+		for _, e := range g.Entities {
+			if e.Dead {
+				continue
+			}
+			t := ENEMY_TYPES[e.TypeName]
+			if !aabbOverlap(p.X, p.Y, p.Width, p.Height, e.X, e.Y, t.Width, t.Height) {
+				continue
+			}
+			e.Health -= p.Damage
+			if e.Health <= 0 {
+				e.Dead = true
+				e.DeathTimer = enemyDeathAnimTicks
+			}
+			p.Dead = true
+			break
+		}
+
+		if !p.Dead {
+			alive = append(alive, p)
+		}
+	}
+	g.Projectiles = alive
+}
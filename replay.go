@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// InputFrame is the input state sampled on a single tick: everything
+// needed to reproduce that tick's behavior given the same world seed.
+type InputFrame struct {
+	Left, Right, Jump, Down               bool
+	MineHeld, ShootPressed, TorchPressed  bool
+	CursorX, CursorY                      int32
+}
+
+// currentInputFrame returns this tick's input, either read live from
+// ebiten or replayed from a previously recorded stream. When recording,
+// the live frame is appended to ReplayFrames as it's read.
+func (g *Game) currentInputFrame() InputFrame {
+	if g.ReplayPlayback {
+		if g.ReplayIndex >= len(g.ReplayFrames) {
+			return InputFrame{}
+		}
+		frame := g.ReplayFrames[g.ReplayIndex]
+		g.ReplayIndex++
+		return frame
+	}
+
+	x, y := ebiten.CursorPosition()
+	frame := InputFrame{
+		Left:         ebiten.IsKeyPressed(ebiten.KeyLeft),
+		Right:        ebiten.IsKeyPressed(ebiten.KeyRight),
+		Jump:         ebiten.IsKeyPressed(ebiten.KeySpace),
+		Down:         ebiten.IsKeyPressed(ebiten.KeyDown),
+		MineHeld:     ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+		ShootPressed: inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight),
+		TorchPressed: inpututil.IsKeyJustPressed(ebiten.KeyT),
+		CursorX:      int32(x),
+		CursorY:      int32(y),
+	}
+	if g.ReplayRecording {
+		g.ReplayFrames = append(g.ReplayFrames, frame)
+	}
+	return frame
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// SaveReplay writes the world seed and every recorded input frame to a
+// compact binary stream, so the run can be reproduced exactly later.
+func (g *Game) SaveReplay(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("save replay: %w", err)
+	}
+	defer f.Close()
+
+	if err := binary.Write(f, binary.LittleEndian, g.Seed); err != nil {
+		return fmt.Errorf("save replay: %w", err)
+	}
+
+	for _, frame := range g.ReplayFrames {
+		flags := []byte{
+			boolByte(frame.Left), boolByte(frame.Right), boolByte(frame.Jump), boolByte(frame.Down),
+			boolByte(frame.MineHeld), boolByte(frame.ShootPressed), boolByte(frame.TorchPressed),
+		}
+		if _, err := f.Write(flags); err != nil {
+			return fmt.Errorf("save replay: %w", err)
+		}
+		if err := binary.Write(f, binary.LittleEndian, frame.CursorX); err != nil {
+			return fmt.Errorf("save replay: %w", err)
+		}
+		if err := binary.Write(f, binary.LittleEndian, frame.CursorY); err != nil {
+			return fmt.Errorf("save replay: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadReplay resets the game to a fresh world on the replay's seed and
+// queues up its frames for deterministic playback.
+func (g *Game) LoadReplay(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("load replay: %w", err)
+	}
+	defer f.Close()
+
+	var seed int64
+	if err := binary.Read(f, binary.LittleEndian, &seed); err != nil {
+		return fmt.Errorf("load replay: %w", err)
+	}
+
+	g.Seed = seed
+	g.World = make(map[[2]int]*Chunk)
+	g.ModifiedBlocks = make(map[[2]int]string)
+	g.createPlayer()
+
+	var frames []InputFrame
+	flags := make([]byte, 7)
+	for {
+		if _, err := io.ReadFull(f, flags); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("load replay: %w", err)
+		}
+		var cx, cy int32
+		if err := binary.Read(f, binary.LittleEndian, &cx); err != nil {
+			return fmt.Errorf("load replay: %w", err)
+		}
+		if err := binary.Read(f, binary.LittleEndian, &cy); err != nil {
+			return fmt.Errorf("load replay: %w", err)
+		}
+		frames = append(frames, InputFrame{
+			Left: flags[0] == 1, Right: flags[1] == 1, Jump: flags[2] == 1, Down: flags[3] == 1,
+			MineHeld: flags[4] == 1, ShootPressed: flags[5] == 1, TorchPressed: flags[6] == 1,
+			CursorX: cx, CursorY: cy,
+		})
+	}
+
+	g.ReplayFrames = frames
+	g.ReplayPlayback = true
+	g.ReplayIndex = 0
+	g.streamChunks()
+	return nil
+}
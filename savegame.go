@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// saveData is the on-disk save format: the RNG seed (so procedural
+// chunks reproduce identically), player state, time of day, and the
+// sparse diff of blocks the player has mined or placed.
+type saveData struct {
+	Seed           int64
+	Tick           int64
+	TimeOfDay      float64
+	TotalMined     int
+	PlayerX        float64
+	PlayerY        float64
+	PlayerVelX     float64
+	PlayerVelY     float64
+	PlayerHealth   float64
+	PlayerMaxHP    float64
+	Inventory      map[string]int
+	ModifiedBlocks map[string]string // "x,y" -> block type, "" meaning mined out
+}
+
+// Save persists the game to path: the seed, player state and the sparse
+// block diff are enough to reconstruct the world exactly via Load.
+func (g *Game) Save(path string) error {
+	data := saveData{
+		Seed:         g.Seed,
+		Tick:         g.Tick,
+		TimeOfDay:    g.TimeOfDay,
+		TotalMined:   g.TotalMined,
+		PlayerX:      g.Player.X,
+		PlayerY:      g.Player.Y,
+		PlayerVelX:   g.Player.VelX,
+		PlayerVelY:   g.Player.VelY,
+		PlayerHealth: g.Player.Health,
+		PlayerMaxHP:  g.Player.MaxHealth,
+		Inventory:    g.Player.Inventory,
+
+		ModifiedBlocks: make(map[string]string, len(g.ModifiedBlocks)),
+	}
+	for key, blockType := range g.ModifiedBlocks {
+		data.ModifiedBlocks[fmt.Sprintf("%d,%d", key[0], key[1])] = blockType
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the game's state with what was persisted at path. The
+// world itself isn't stored: it's regenerated deterministically from
+// the seed, then the recorded block diff is reapplied as chunks load.
+func (g *Game) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	defer f.Close()
+
+	var data saveData
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+
+	g.Seed = data.Seed
+	g.Tick = data.Tick
+	g.TimeOfDay = data.TimeOfDay
+	g.TotalMined = data.TotalMined
+	g.World = make(map[[2]int]*Chunk)
+
+	g.ModifiedBlocks = make(map[[2]int]string, len(data.ModifiedBlocks))
+	for key, blockType := range data.ModifiedBlocks {
+		var x, y int
+		if _, err := fmt.Sscanf(key, "%d,%d", &x, &y); err != nil {
+			continue
+		}
+		g.ModifiedBlocks[[2]int{x, y}] = blockType
+	}
+
+	g.Player = &Player{
+		X: data.PlayerX, Y: data.PlayerY,
+		VelX: data.PlayerVelX, VelY: data.PlayerVelY,
+		Width: TILE_SIZE, Height: playerStandHeight,
+		Health: data.PlayerHealth, MaxHealth: data.PlayerMaxHP,
+		Inventory: data.Inventory,
+		Facing:    "right",
+	}
+	g.streamChunks()
+	return nil
+}